@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// resolve applies action to file, a duplicate of original: "delete" removes file, while "hardlink" and "symlink"
+// replace it with a link to original instead.
+func resolve(file string, original string, action string) error {
+	switch action {
+	case "delete":
+		return os.Remove(file)
+	case "hardlink":
+		if !sameDevice(file, original) {
+			return fmt.Errorf("%v: skipping, %v is on a different filesystem", file, original)
+		}
+
+		return replaceWithLink(file, original, true)
+	case "symlink":
+		return replaceWithLink(file, original, false)
+	default:
+		return fmt.Errorf("%v: unknown action %q", file, action)
+	}
+}
+
+// sameDevice reports whether a and b reside on the same filesystem, based on inode information. If inode
+// information isn't available on the current platform it conservatively reports false.
+func sameDevice(a string, b string) bool {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+
+	devA, _, _, okA := getDevIno(infoA)
+	devB, _, _, okB := getDevIno(infoB)
+
+	return okA && okB && devA == devB
+}
+
+// replaceWithLink atomically replaces the file at path with a hard link (hardlink true) or symbolic link (hardlink
+// false) to original. The new link is created at a temporary path in the same directory as path and then renamed
+// over it, so path is never briefly missing if the process is interrupted partway through.
+func replaceWithLink(path string, original string, hardlink bool) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".dup-*")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := tmp.Name()
+	tmp.Close()
+	os.Remove(tmpPath)
+
+	if hardlink {
+		err = os.Link(original, tmpPath)
+	} else {
+		target, absErr := filepath.Abs(original)
+		if absErr != nil {
+			target = original
+		}
+
+		err = os.Symlink(target, tmpPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
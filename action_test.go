@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplaceWithLinkHardlink(t *testing.T) {
+	dir := t.TempDir()
+	original := writeTestFile(t, dir, "original", []byte("content"))
+	dup := writeTestFile(t, dir, "dup", []byte("content"))
+
+	if err := replaceWithLink(dup, original, true); err != nil {
+		t.Fatalf("replaceWithLink: %v", err)
+	}
+
+	info, err := os.Lstat(dup)
+	if err != nil {
+		t.Fatalf("Lstat(%v): %v", dup, err)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		t.Error("dup is a symlink, want a hard link")
+	}
+
+	origInfo, err := os.Stat(original)
+	if err != nil {
+		t.Fatalf("Stat(%v): %v", original, err)
+	}
+	if !os.SameFile(info, origInfo) {
+		t.Error("dup does not share an inode with original after hardlinking")
+	}
+}
+
+func TestReplaceWithLinkSymlink(t *testing.T) {
+	dir := t.TempDir()
+	original := writeTestFile(t, dir, "original", []byte("content"))
+	dup := writeTestFile(t, dir, "dup", []byte("content"))
+
+	if err := replaceWithLink(dup, original, false); err != nil {
+		t.Fatalf("replaceWithLink: %v", err)
+	}
+
+	info, err := os.Lstat(dup)
+	if err != nil {
+		t.Fatalf("Lstat(%v): %v", dup, err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Error("dup is not a symlink after symlinking")
+	}
+
+	target, err := os.Readlink(dup)
+	if err != nil {
+		t.Fatalf("Readlink(%v): %v", dup, err)
+	}
+	absOriginal, err := filepath.Abs(original)
+	if err != nil {
+		t.Fatalf("Abs(%v): %v", original, err)
+	}
+	if target != absOriginal {
+		t.Errorf("symlink target = %v, want %v", target, absOriginal)
+	}
+}
+
+func TestReplaceWithLinkNeverLeavesPathMissing(t *testing.T) {
+	dir := t.TempDir()
+	dup := writeTestFile(t, dir, "dup", []byte("content"))
+
+	// original does not exist under this name, so the link creation step should fail, and the rename that
+	// replaces dup must never be reached: dup should be left exactly as it was.
+	if err := replaceWithLink(dup, filepath.Join(dir, "missing"), true); err == nil {
+		t.Fatal("replaceWithLink succeeded despite a missing original")
+	}
+
+	if _, err := os.Stat(dup); err != nil {
+		t.Errorf("dup is missing after a failed replaceWithLink: %v", err)
+	}
+}
+
+func TestResolveDelete(t *testing.T) {
+	dir := t.TempDir()
+	original := writeTestFile(t, dir, "original", []byte("content"))
+	dup := writeTestFile(t, dir, "dup", []byte("content"))
+
+	if err := resolve(dup, original, "delete"); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+
+	if _, err := os.Stat(dup); !os.IsNotExist(err) {
+		t.Errorf("dup still exists after delete, stat err = %v", err)
+	}
+}
+
+func TestResolveUnknownAction(t *testing.T) {
+	dir := t.TempDir()
+	original := writeTestFile(t, dir, "original", []byte("content"))
+	dup := writeTestFile(t, dir, "dup", []byte("content"))
+
+	if err := resolve(dup, original, "bogus"); err == nil {
+		t.Error("resolve with an unknown action returned nil error")
+	}
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// quickHashSize is the number of bytes read from the head and tail of a file to compute its quick hash.
+const quickHashSize = 8 * 1024
+
+// inodeKey identifies a file by device and inode number, used to recognize hardlinks to the same underlying file.
+type inodeKey struct {
+	dev uint64
+	ino uint64
+}
+
+// skipHardlink reports whether the file described by info is a hardlink to a file already recorded in seen. If so,
+// it should not be listed again. Otherwise it records the file in seen (when the platform supports inodes) and
+// returns false.
+func skipHardlink(info os.FileInfo, seen map[inodeKey]bool) bool {
+	dev, ino, nlink, ok := getDevIno(info)
+	if !ok || nlink < 2 {
+		return false
+	}
+
+	key := inodeKey{dev, ino}
+	if seen[key] {
+		return true
+	}
+
+	seen[key] = true
+
+	return false
+}
+
+// listDir retrieves a list of all files in the directory, recursively traversing the tree if specified. Symlinks are
+// skipped outright rather than followed, since their own metadata (size, mtime) differs from their target's while
+// os.Open reads through them, which would make filtering and hashing disagree about what a symlink even is. If
+// followHardlinks is true, entries which are hardlinks to a file already seen (tracked via seen) are skipped.
+// Entries which don't pass filters are skipped, and excluded directories are not descended into.
+func listDir(dir string, recursive bool, followHardlinks bool, seen map[inodeKey]bool, filters Filters) []string {
+	var files []string
+	if recursive {
+		// If recursive is specified use WalkDir to traverse the directory tree and collect all files.
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if d.IsDir() {
+				if path != dir && matchesAny(filters.Exclude, path) {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			if d.Type()&os.ModeSymlink != 0 {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if followHardlinks && skipHardlink(info, seen) {
+				return nil
+			}
+
+			if !filters.match(path, info) {
+				return nil
+			}
+
+			files = append(files, path)
+
+			return nil
+		})
+	} else {
+		// Otherwise just use ReadDir to read files.
+		f, err := os.Open(dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		ds, err := f.ReadDir(0)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, d := range ds {
+			if d.IsDir() || d.Type()&os.ModeSymlink != 0 {
+				continue
+			}
+
+			path := filepath.Join(dir, d.Name())
+
+			info, err := d.Info()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if followHardlinks && skipHardlink(info, seen) {
+				continue
+			}
+
+			if !filters.match(path, info) {
+				continue
+			}
+
+			files = append(files, path)
+		}
+
+		f.Close()
+	}
+
+	return files
+}
+
+// readPaths reads a list of paths from r, one per line, and returns them. If null is true paths are NUL-terminated
+// instead, matching the output of commands such as "find -print0".
+func readPaths(r io.Reader, null bool) []string {
+	sep := byte('\n')
+	if null {
+		sep = 0
+	}
+
+	var paths []string
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString(sep)
+		line = strings.TrimSuffix(line, string(sep))
+		if line != "" {
+			paths = append(paths, line)
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	return paths
+}
+
+// filterFiles applies hardlink deduplication and filters to an explicit list of files, e.g. one supplied via stdin
+// rather than discovered by listDir. Like listDir, it skips symlinks outright rather than following them, so a file
+// is treated the same way regardless of whether it was discovered by directory walk or piped via stdin.
+func filterFiles(files []string, followHardlinks bool, seen map[inodeKey]bool, filters Filters) []string {
+	var out []string
+	for _, file := range files {
+		info, err := os.Lstat(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			continue
+		}
+
+		if followHardlinks && skipHardlink(info, seen) {
+			continue
+		}
+
+		if !filters.match(file, info) {
+			continue
+		}
+
+		out = append(out, file)
+	}
+
+	return out
+}
+
+// quickHash computes a cheap hash over the first and last quickHashSize bytes of the file at path. It is used to
+// split a same-size bucket into sub-buckets without reading every file in full.
+func quickHash(path string, size int64) string {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+
+	if size <= int64(2*quickHashSize) {
+		if _, err := io.Copy(h, f); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		buf := make([]byte, quickHashSize)
+
+		n, err := io.ReadFull(f, buf)
+		if err != nil {
+			log.Fatal(err)
+		}
+		h.Write(buf[:n])
+
+		if _, err := f.Seek(-int64(quickHashSize), io.SeekEnd); err != nil {
+			log.Fatal(err)
+		}
+
+		n, err = io.ReadFull(f, buf)
+		if err != nil {
+			log.Fatal(err)
+		}
+		h.Write(buf[:n])
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fullHash computes a SHA-256 hash over the entire contents of the file at path.
+func fullHash(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		log.Fatal(err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// bucketBySize groups files by size, discarding buckets with only a single file since those cannot contain
+// duplicates.
+func bucketBySize(files []string) map[int64][]string {
+	buckets := make(map[int64][]string)
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		size := info.Size()
+		buckets[size] = append(buckets[size], file)
+	}
+
+	for size, bucket := range buckets {
+		if len(bucket) < 2 {
+			delete(buckets, size)
+		}
+	}
+
+	return buckets
+}
+
+// refine splits bucket into sub-buckets keyed by the value hashFn returns for each file, discarding sub-buckets with
+// only a single file. Hashes are computed concurrently using up to jobs workers.
+func refine(bucket []string, jobs int, hashFn func(file string) string) map[string][]string {
+	hashes := parallelHash(bucket, jobs, hashFn)
+
+	sub := make(map[string][]string)
+	for i, file := range bucket {
+		sub[hashes[i]] = append(sub[hashes[i]], file)
+	}
+
+	for hash, group := range sub {
+		if len(group) < 2 {
+			delete(sub, hash)
+		}
+	}
+
+	return sub
+}
+
+// DupGroup is a group of files which are byte-for-byte identical, along with the size and full content hash they
+// share. By convention Files[0] is treated as the original to keep, and the rest as duplicates of it.
+type DupGroup struct {
+	Hash  string   `json:"hash"`
+	Size  int64    `json:"size"`
+	Files []string `json:"files"`
+}
+
+// findDups takes a list of files and returns groups of files which are byte-for-byte identical. Files are first
+// bucketed by size, then refined using a quick hash over the start and end of each file, and finally confirmed with
+// a full content hash, so every file is read in full at most once. Hashing is parallelized across up to jobs
+// workers.
+func findDups(files []string, jobs int) []DupGroup {
+	var dups []DupGroup
+
+	for size, sizeBucket := range bucketBySize(files) {
+		// For files this small, quickHash already reads the whole file to produce a SHA-256 digest, so it is the
+		// full content hash and a separate full-hash pass would just read every file a second time for nothing.
+		if size <= 2*quickHashSize {
+			for hash, group := range refine(sizeBucket, jobs, func(file string) string { return quickHash(file, size) }) {
+				dups = append(dups, DupGroup{Hash: hash, Size: size, Files: group})
+			}
+
+			continue
+		}
+
+		quickSub := refine(sizeBucket, jobs, func(file string) string { return quickHash(file, size) })
+
+		for _, quickGroup := range quickSub {
+			for hash, group := range refine(quickGroup, jobs, fullHash) {
+				dups = append(dups, DupGroup{Hash: hash, Size: size, Files: group})
+			}
+		}
+	}
+
+	// Sort files within each group, and groups themselves, for stable output.
+	for _, group := range dups {
+		sort.Strings(group.Files)
+	}
+	sort.Slice(dups, func(i, j int) bool {
+		return dups[i].Files[0] < dups[j].Files[0]
+	})
+
+	return dups
+}
+
+// filterCross removes groups all of whose files originate from the same input directory. It is used to honour the
+// --cross flag, which restricts results to duplicates that span more than one input directory.
+func filterCross(groups []DupGroup, dirOf map[string]int) []DupGroup {
+	var filtered []DupGroup
+	for _, group := range groups {
+		first := dirOf[group.Files[0]]
+
+		spansDirs := false
+		for _, file := range group.Files[1:] {
+			if dirOf[file] != first {
+				spansDirs = true
+				break
+			}
+		}
+
+		if spansDirs {
+			filtered = append(filtered, group)
+		}
+	}
+
+	return filtered
+}
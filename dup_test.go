@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, dir string, name string, content []byte) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatalf("WriteFile(%v): %v", path, err)
+	}
+
+	return path
+}
+
+func TestQuickHashMatchesFullHashForSmallFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	// A file within the head+tail window should be read in full, so its quick hash must equal its full hash.
+	content := make([]byte, quickHashSize) // exactly one read, well under 2*quickHashSize
+	for i := range content {
+		content[i] = byte(i)
+	}
+	path := writeTestFile(t, dir, "small", content)
+
+	if got, want := quickHash(path, int64(len(content))), fullHash(path); got != want {
+		t.Errorf("quickHash = %v, want %v (== fullHash)", got, want)
+	}
+}
+
+func TestQuickHashIgnoresMiddleForLargeFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	size := int64(4*quickHashSize) + 1
+	a := make([]byte, size)
+	b := make([]byte, size)
+	copy(a, b) // identical head/tail, differing middle
+	a[2*quickHashSize] = 0xff
+	b[2*quickHashSize] = 0x00
+
+	pathA := writeTestFile(t, dir, "a", a)
+	pathB := writeTestFile(t, dir, "b", b)
+
+	if quickHash(pathA, size) != quickHash(pathB, size) {
+		t.Error("quickHash should only look at head and tail, so differing middles must still collide")
+	}
+	if fullHash(pathA) == fullHash(pathB) {
+		t.Error("fullHash should see the differing middle byte and not match")
+	}
+}
+
+func TestQuickHashBoundaryBetweenReadModes(t *testing.T) {
+	dir := t.TempDir()
+
+	// At exactly 2*quickHashSize the file is still read in full (the <= branch), rather than head+tail.
+	size := int64(2 * quickHashSize)
+	content := make([]byte, size)
+	for i := range content {
+		content[i] = byte(i)
+	}
+	path := writeTestFile(t, dir, "boundary", content)
+
+	if got, want := quickHash(path, size), fullHash(path); got != want {
+		t.Errorf("quickHash at the 2*quickHashSize boundary = %v, want %v (== fullHash)", got, want)
+	}
+}
+
+func TestFindDups(t *testing.T) {
+	dir := t.TempDir()
+
+	a := writeTestFile(t, dir, "a", []byte("duplicate content"))
+	b := writeTestFile(t, dir, "b", []byte("duplicate content"))
+	writeTestFile(t, dir, "c", []byte("unique content"))
+
+	dups := findDups([]string{a, b}, 2)
+	if len(dups) != 1 {
+		t.Fatalf("findDups returned %v groups, want 1", len(dups))
+	}
+	if len(dups[0].Files) != 2 {
+		t.Fatalf("group has %v files, want 2", len(dups[0].Files))
+	}
+}
+
+func TestFindDupsNoMatches(t *testing.T) {
+	dir := t.TempDir()
+
+	a := writeTestFile(t, dir, "a", []byte("one"))
+	b := writeTestFile(t, dir, "b", []byte("two"))
+
+	if dups := findDups([]string{a, b}, 2); len(dups) != 0 {
+		t.Errorf("findDups returned %v groups for distinct files, want 0", len(dups))
+	}
+}
+
+func TestBucketBySizeDropsSingletons(t *testing.T) {
+	dir := t.TempDir()
+
+	a := writeTestFile(t, dir, "a", []byte("xx"))
+	b := writeTestFile(t, dir, "b", []byte("xx"))
+	c := writeTestFile(t, dir, "c", []byte("y"))
+
+	buckets := bucketBySize([]string{a, b, c})
+	if len(buckets) != 1 {
+		t.Fatalf("bucketBySize returned %v buckets, want 1 (singleton size dropped)", len(buckets))
+	}
+	if bucket, ok := buckets[2]; !ok || len(bucket) != 2 {
+		t.Errorf("bucket for size 2 = %v, want [a b]", bucket)
+	}
+}
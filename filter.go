@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// timeLayouts are the accepted formats for the --newer-than and --older-than flags, tried in order.
+var timeLayouts = []string{time.RFC3339, "2006-01-02"}
+
+// parseTime parses s using timeLayouts, returning an error listing the accepted formats if none match.
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%q does not match any of the accepted formats (%v)", s, timeLayouts)
+}
+
+// Filters holds the predicates applied to files discovered while listing a directory tree. A zero Filters value
+// matches every file.
+type Filters struct {
+	MinSize   int64     // Files smaller than this are skipped. Zero means no lower bound.
+	MaxSize   int64     // Files larger than this are skipped. Zero means no upper bound.
+	Include   []string  // If non-empty, a file must match at least one of these globs.
+	Exclude   []string  // A file matching any of these globs is skipped.
+	NewerThan time.Time // Files modified before this are skipped. Zero means no lower bound.
+	OlderThan time.Time // Files modified after this are skipped. Zero means no upper bound.
+}
+
+// validateGlobs checks that every pattern in patterns is a syntactically valid filepath.Match glob, returning an
+// error naming the first invalid one.
+func validateGlobs(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%q: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// matchGlob reports whether pattern matches either path's basename or the full path.
+func matchGlob(pattern string, path string) bool {
+	if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+		return true
+	}
+
+	ok, _ := filepath.Match(pattern, path)
+
+	return ok
+}
+
+// matchesAny reports whether path matches any of the given globs.
+func matchesAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// match reports whether the file at path, described by info, passes every predicate in f.
+func (f Filters) match(path string, info os.FileInfo) bool {
+	size := info.Size()
+	if f.MinSize > 0 && size < f.MinSize {
+		return false
+	}
+	if f.MaxSize > 0 && size > f.MaxSize {
+		return false
+	}
+
+	if len(f.Include) > 0 && !matchesAny(f.Include, path) {
+		return false
+	}
+	if matchesAny(f.Exclude, path) {
+		return false
+	}
+
+	modTime := info.ModTime()
+	if !f.NewerThan.IsZero() && modTime.Before(f.NewerThan) {
+		return false
+	}
+	if !f.OlderThan.IsZero() && modTime.After(f.OlderThan) {
+		return false
+	}
+
+	return true
+}
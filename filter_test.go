@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2024-03-05", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{"2024-03-05T10:00:00Z", time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseTime(tt.in)
+		if err != nil {
+			t.Errorf("parseTime(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseTime(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseTimeInvalid(t *testing.T) {
+	if _, err := parseTime("not a time"); err == nil {
+		t.Error("parseTime(\"not a time\") returned nil error, want non-nil")
+	}
+}
+
+func TestValidateGlobs(t *testing.T) {
+	if err := validateGlobs([]string{"*.txt", "foo?bar"}); err != nil {
+		t.Errorf("validateGlobs returned error for valid globs: %v", err)
+	}
+	if err := validateGlobs([]string{"[invalid"}); err == nil {
+		t.Error("validateGlobs returned nil error for an invalid glob")
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.txt", "dir/sub/file.txt", true},
+		{"*.txt", "dir/sub/file.go", false},
+		{"dir/sub/*.txt", "dir/sub/file.txt", true},
+		{"other/*.txt", "dir/sub/file.txt", false},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+type fakeFileInfo struct {
+	size    int64
+	modTime time.Time
+}
+
+func (f fakeFileInfo) Name() string       { return "fake" }
+func (f fakeFileInfo) Size() int64        { return f.size }
+func (f fakeFileInfo) Mode() os.FileMode  { return 0 }
+func (f fakeFileInfo) ModTime() time.Time { return f.modTime }
+func (f fakeFileInfo) IsDir() bool        { return false }
+func (f fakeFileInfo) Sys() any           { return nil }
+
+func TestFiltersMatch(t *testing.T) {
+	info := fakeFileInfo{size: 100, modTime: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name string
+		f    Filters
+		want bool
+	}{
+		{"zero value matches everything", Filters{}, true},
+		{"within size bounds", Filters{MinSize: 50, MaxSize: 150}, true},
+		{"below min size", Filters{MinSize: 101}, false},
+		{"above max size", Filters{MaxSize: 99}, false},
+		{"matching include", Filters{Include: []string{"*.go"}}, true},
+		{"non-matching include", Filters{Include: []string{"*.txt"}}, false},
+		{"matching exclude", Filters{Exclude: []string{"*.go"}}, false},
+		{"before newer-than", Filters{NewerThan: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)}, false},
+		{"after older-than", Filters{OlderThan: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.f.match("file.go", info); got != tt.want {
+				t.Errorf("match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
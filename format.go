@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/rodaine/table"
+)
+
+// printDups renders dups to stdout in the given format, which must be one of "table", "json", "ndjson" or "csv".
+func printDups(dups []DupGroup, format string) error {
+	switch format {
+	case "table":
+		printTable(dups)
+		return nil
+	case "json":
+		return printJSON(dups)
+	case "ndjson":
+		return printNDJSON(dups)
+	case "csv":
+		return printCSV(dups)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// printTable prints dups as a human-readable table, one per group, marking the first file of each group as the one
+// being kept.
+func printTable(dups []DupGroup) {
+	headerFmt := color.New(color.Italic).Add(color.Underline).SprintfFunc()
+	for i, group := range dups {
+		fmt.Printf("Group %v:\n", i+1)
+
+		tbl := table.New("File", "Status")
+		tbl.WithHeaderFormatter(headerFmt)
+		for j, file := range group.Files {
+			status := "duplicate"
+			if j == 0 {
+				status = "kept"
+			}
+			tbl.AddRow(file, status)
+		}
+		tbl.Print()
+		fmt.Println()
+	}
+}
+
+// printJSON prints dups as a single indented JSON array of groups.
+func printJSON(dups []DupGroup) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(dups)
+}
+
+// printNDJSON prints dups as newline-delimited JSON, one group per line.
+func printNDJSON(dups []DupGroup) error {
+	enc := json.NewEncoder(os.Stdout)
+	for _, group := range dups {
+		if err := enc.Encode(group); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// printCSV prints dups as CSV with columns hash, size, file and kept. Every file in every group gets its own row.
+func printCSV(dups []DupGroup) error {
+	cw := csv.NewWriter(os.Stdout)
+
+	if err := cw.Write([]string{"hash", "size", "file", "kept"}); err != nil {
+		return err
+	}
+
+	for _, group := range dups {
+		for j, file := range group.Files {
+			kept := "false"
+			if j == 0 {
+				kept = "true"
+			}
+
+			row := []string{group.Hash, fmt.Sprint(group.Size), file, kept}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
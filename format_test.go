@@ -0,0 +1,92 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureStdout redirects os.Stdout for the duration of fn and returns what was written to it.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe: %v", err)
+	}
+
+	orig := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = orig }()
+
+	fn()
+
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+
+	return string(out)
+}
+
+func testGroups() []DupGroup {
+	return []DupGroup{
+		{Hash: "abc123", Size: 42, Files: []string{"keep.txt", "dup.txt"}},
+	}
+}
+
+func TestPrintDupsJSON(t *testing.T) {
+	out := captureStdout(t, func() {
+		if err := printDups(testGroups(), "json"); err != nil {
+			t.Fatalf("printDups: %v", err)
+		}
+	})
+
+	for _, want := range []string{"abc123", "keep.txt", "dup.txt", "42"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("json output missing %q:\n%v", want, out)
+		}
+	}
+}
+
+func TestPrintDupsNDJSON(t *testing.T) {
+	out := captureStdout(t, func() {
+		if err := printDups(testGroups(), "ndjson"); err != nil {
+			t.Fatalf("printDups: %v", err)
+		}
+	})
+
+	if got := strings.Count(out, "\n"); got != 1 {
+		t.Errorf("ndjson output has %v lines, want 1 per group", got)
+	}
+}
+
+func TestPrintDupsCSV(t *testing.T) {
+	out := captureStdout(t, func() {
+		if err := printDups(testGroups(), "csv"); err != nil {
+			t.Fatalf("printDups: %v", err)
+		}
+	})
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("csv output has %v lines, want 3 (header + 2 files): %q", len(lines), out)
+	}
+	if lines[0] != "hash,size,file,kept" {
+		t.Errorf("csv header = %q, want %q", lines[0], "hash,size,file,kept")
+	}
+	if !strings.HasSuffix(lines[1], "keep.txt,true") {
+		t.Errorf("first row = %q, want it to mark keep.txt as kept", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], "dup.txt,false") {
+		t.Errorf("second row = %q, want it to mark dup.txt as not kept", lines[2])
+	}
+}
+
+func TestPrintDupsUnknownFormat(t *testing.T) {
+	if err := printDups(testGroups(), "xml"); err == nil {
+		t.Error("printDups with an unknown format returned nil error")
+	}
+}
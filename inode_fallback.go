@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// getDevIno always fails on platforms without inode semantics (e.g. Windows, Plan 9).
+func getDevIno(info os.FileInfo) (dev uint64, ino uint64, nlink uint64, ok bool) {
+	return 0, 0, 0, false
+}
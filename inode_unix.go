@@ -0,0 +1,19 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// getDevIno extracts the device and inode numbers from info, along with its hard link count. ok is false if the
+// underlying syscall stat information could not be obtained.
+func getDevIno(info os.FileInfo) (dev uint64, ino uint64, nlink uint64, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, 0, false
+	}
+
+	return uint64(stat.Dev), stat.Ino, uint64(stat.Nlink), true
+}
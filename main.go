@@ -3,224 +3,139 @@ Dup finds duplicate files in a list of given directories and optionally deletes
 
 Usage:
 
-	dup [flags] dir ...
+	dup [flags] (dir | -) ...
 
 The flags are:
 
-	-x, --cross       Cross check across directories.
-	-h, --help        Print this help.
-	-r, --recursive   Recursively check files.
-
-Dup only compares files which have the same size. The files are compared byte for byte and marked as duplicates if they
-are same. Once all files are processed a table of duplicate files founds and their matches are displayed. The user is
-then provided with an option for deleting all duplicates detected.
+	    --action ACTION      Resolve duplicates non-interactively: delete, hardlink, symlink or report.
+	-x, --cross              Cross check across directories.
+	    --exclude GLOB       Skip files matching GLOB (basename or full path). Repeatable.
+	    --follow-hardlinks   Detect hardlinked files and only consider one of each set (default true).
+	    --format FORMAT      Output format: table, json, ndjson or csv (default table).
+	    --from-stdin         Read the list of files to check from stdin instead of walking directories.
+	-h, --help               Print this help.
+	    --include GLOB       Only consider files matching GLOB (basename or full path). Repeatable.
+	-j, --jobs N             Number of concurrent workers used for hashing (default number of CPUs).
+	    --max-size BYTES     Skip files larger than BYTES.
+	    --min-size BYTES     Skip files smaller than BYTES.
+	    --newer-than TIME    Skip files last modified before TIME (RFC3339 or YYYY-MM-DD).
+	    --null               Read NUL-terminated paths from stdin (see --from-stdin) instead of newline-terminated.
+	    --older-than TIME    Skip files last modified after TIME (RFC3339 or YYYY-MM-DD).
+	-r, --recursive          Recursively check files.
+
+Dup first buckets files by size, then narrows each bucket down using a quick hash of the start and end of the file,
+and finally confirms matches with a full content hash. This means every file is read in full at most once, and it
+scales far better than a pairwise byte-for-byte comparison on large trees.
+
+In place of a directory argument, "-" (or --from-stdin) reads a list of file paths from stdin, one per line (or
+NUL-terminated with --null), instead of walking a directory tree. --null only affects how paths are read from
+stdin: the json, ndjson and csv output formats already escape/quote embedded newlines in file paths (and are the
+right choice for piping dup's results into other tools), so there is no separate NUL-terminated output mode.
+
+The --min-size, --max-size, --include, --exclude, --newer-than and --older-than flags restrict which files are
+considered, and are applied while walking so excluded directories are not even descended into. Symlinks are never
+considered as candidates, whether discovered by walking a directory or supplied via --from-stdin.
+
+With the table output format, once all files are processed groups of duplicate files are displayed, keeping the
+first file of each group as the original. The user is then asked how to resolve the remaining duplicates in each
+group: by deleting them, or replacing them with a hard or symbolic link to the original. Passing --action skips the
+prompt and applies the given action to every group. The json, ndjson and csv formats print the duplicate groups for
+consumption by other tools and never prompt or modify anything, so --action cannot be combined with them.
 */
 package main
 
 import (
-	"bytes"
 	"fmt"
-	"io"
-	"io/fs"
 	"log"
 	"os"
-	"path/filepath"
-	"sort"
+	"runtime"
 
-	"github.com/fatih/color"
-	"github.com/rodaine/table"
 	"github.com/spf13/pflag"
 )
 
-// Number of bytes to read at once while comparing files.
-const CHUNK_SIZE = 1024 * 1024
-
-// listDir retrieves a list of all files in the directory, recursively traversing the tree if specified.
-func listDir(dir string, recursive bool) []string {
-	var files []string
-	if recursive {
-		// If recursive is specified use WalkDir to traverse the directory tree and collect all files.
-		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			if !d.IsDir() {
-				files = append(files, path)
-			}
-
-			return nil
-		})
-	} else {
-		// Otherwise just use ReadDir to read files.
-		f, err := os.Open(dir)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		ds, err := f.ReadDir(0)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		for _, d := range ds {
-			if !d.IsDir() {
-				files = append(files, filepath.Join(dir, d.Name()))
-			}
-		}
-
-		f.Close()
-	}
-
-	return files
-}
-
-// makePairs generates pairs of files to compare against each other. If cross is specified files within the same
-// directory are not compared.
-func makePairs(files [][]string, cross bool) [][2]string {
-	var pairs [][2]string
+func main() {
+	log.SetFlags(0)
 
-	for i := 0; i < len(files); i++ {
-		// If cross is false compare within directory as well.
-		if !cross {
-			for x := 0; x < len(files[i]); x++ {
-				for y := x + 1; y < len(files[i]); y++ {
-					pairs = append(pairs, [2]string{files[i][x], files[i][y]})
-				}
-			}
-		}
+	// Define and parse command line arguments.
+	help := pflag.BoolP("help", "h", false, "Print this help.")
+	cross := pflag.BoolP("cross", "x", false, "Cross check across directories.")
+	recursive := pflag.BoolP("recursive", "r", false, "Recursively check files.")
+	followHardlinks := pflag.Bool("follow-hardlinks", true, "Detect hardlinked files and only consider one of each set.")
+	jobs := pflag.IntP("jobs", "j", runtime.NumCPU(), "Number of concurrent workers used for hashing.")
+	action := pflag.String("action", "", "Resolve duplicates non-interactively: delete, hardlink, symlink or report.")
+	format := pflag.String("format", "table", "Output format: table, json, ndjson or csv.")
+	fromStdin := pflag.Bool("from-stdin", false, "Read the list of files to check from stdin instead of walking directories.")
+	null := pflag.Bool("null", false, "Read NUL-terminated paths from stdin (see --from-stdin) instead of newline-terminated.")
+	minSize := pflag.Int64("min-size", 0, "Skip files smaller than this many bytes.")
+	maxSize := pflag.Int64("max-size", 0, "Skip files larger than this many bytes.")
+	include := pflag.StringArray("include", nil, "Only consider files matching this glob (basename or full path). Repeatable.")
+	exclude := pflag.StringArray("exclude", nil, "Skip files matching this glob (basename or full path). Repeatable.")
+	newerThan := pflag.String("newer-than", "", "Skip files last modified before this time (RFC3339 or YYYY-MM-DD).")
+	olderThan := pflag.String("older-than", "", "Skip files last modified after this time (RFC3339 or YYYY-MM-DD).")
+	pflag.Parse()
 
-		for j := i + 1; j < len(files); j++ {
-			for _, file1 := range files[i] {
-				for _, file2 := range files[j] {
-					pairs = append(pairs, [2]string{file1, file2})
-				}
-			}
-		}
+	// If --help is present print help and exit.
+	if *help {
+		fmt.Println("Usage: dup [flags] (dir | -) ...")
+		pflag.PrintDefaults()
+		os.Exit(0)
 	}
 
-	return pairs
-}
-
-// fileCmp reports whether two files are same byte for byte.
-func fileCmp(file1 string, file2 string) bool {
-	// Open both files and get their stats.
-	f1, err := os.Open(file1)
-	if err != nil {
-		log.Fatal(err)
+	validActions := map[string]bool{"": true, "delete": true, "hardlink": true, "symlink": true, "report": true}
+	if !validActions[*action] {
+		fmt.Printf("Invalid action %q: must be one of delete, hardlink, symlink or report.\n", *action)
+		os.Exit(1)
 	}
-	defer f1.Close()
 
-	stat1, err := f1.Stat()
-	if err != nil {
-		log.Fatal(err)
+	validFormats := map[string]bool{"table": true, "json": true, "ndjson": true, "csv": true}
+	if !validFormats[*format] {
+		fmt.Printf("Invalid format %q: must be one of table, json, ndjson or csv.\n", *format)
+		os.Exit(1)
 	}
 
-	f2, err := os.Open(file2)
-	if err != nil {
-		log.Fatal(err)
+	if *format != "table" && *action != "" {
+		fmt.Println("--action cannot be combined with --format other than table: machine-readable formats never modify files.")
+		os.Exit(1)
 	}
-	defer f2.Close()
 
-	stat2, err := f2.Stat()
-	if err != nil {
-		log.Fatal(err)
+	if err := validateGlobs(*include); err != nil {
+		fmt.Printf("Invalid --include: %v\n", err)
+		os.Exit(1)
 	}
-
-	// If files have different sizes they cannot be same.
-	if stat1.Size() != stat2.Size() {
-		f1.Close()
-		f2.Close()
-
-		return false
+	if err := validateGlobs(*exclude); err != nil {
+		fmt.Printf("Invalid --exclude: %v\n", err)
+		os.Exit(1)
 	}
 
-	// Read bytes in chunks and compare them.
-	b1 := make([]byte, CHUNK_SIZE)
-	b2 := make([]byte, CHUNK_SIZE)
-	for {
-		n1, err1 := f1.Read(b1)
-		n2, err2 := f2.Read(b2)
-
-		if err1 == io.EOF && err2 == io.EOF {
-			return true
-		} else if err1 == io.EOF && err2 == nil {
-			return false
-		} else if err1 == nil && err2 == io.EOF {
-			return false
-		} else if err1 != nil || err2 != nil {
-			log.Fatal(err1, err2)
-		}
-
-		if n1 != n2 {
-			return false
-		}
-
-		if n1 < CHUNK_SIZE {
-			b1 = b1[:CHUNK_SIZE]
-			b2 = b2[:CHUNK_SIZE]
-		}
-
-		if !bytes.Equal(b1, b2) {
-			return false
+	filters := Filters{MinSize: *minSize, MaxSize: *maxSize, Include: *include, Exclude: *exclude}
+	if *newerThan != "" {
+		t, err := parseTime(*newerThan)
+		if err != nil {
+			fmt.Printf("Invalid --newer-than: %v\n", err)
+			os.Exit(1)
 		}
+		filters.NewerThan = t
 	}
-}
-
-// findDups takes pairs of files and returns pairs of duplicate files.
-func findDups(pairs [][2]string) [][2]string {
-	dups := make(map[string]string)
-	for _, pair := range pairs {
-		file1, file2 := pair[0], pair[1]
-
-		// If either file has already been marked as a duplicate skip this pair.
-		_, ok1 := dups[file1]
-		_, ok2 := dups[file2]
-		if ok1 || ok2 {
-			continue
-		}
-
-		// Else compare them and mark file2 as duplicate of file1 if needed.
-		if fileCmp(pair[0], pair[1]) {
-			dups[file2] = file1
+	if *olderThan != "" {
+		t, err := parseTime(*olderThan)
+		if err != nil {
+			fmt.Printf("Invalid --older-than: %v\n", err)
+			os.Exit(1)
 		}
+		filters.OlderThan = t
 	}
 
-	// Sort into a list of string pairs.
-	files := make([]string, 0, len(dups))
-	for f := range dups {
-		files = append(files, f)
-	}
-	sort.Slice(files, func(i, j int) bool {
-		return files[i] < files[j]
-	})
-
-	dupsSorted := make([][2]string, 0, len(files))
-	for _, f := range files {
-		dupsSorted = append(dupsSorted, [2]string{f, dups[f]})
-	}
-
-	return dupsSorted
-}
-
-func main() {
-	log.SetFlags(0)
-
-	// Define and parse command line arguments.
-	help := pflag.BoolP("help", "h", false, "Print this help.")
-	cross := pflag.BoolP("cross", "x", false, "Cross check across directories.")
-	recursive := pflag.BoolP("recursive", "r", false, "Recursively check files.")
-	pflag.Parse()
+	// Ensure arguments are valid.
+	dirs := pflag.Args()
+	if *fromStdin {
+		if len(dirs) > 0 {
+			fmt.Println("Directory arguments cannot be combined with --from-stdin.")
+			os.Exit(1)
+		}
 
-	// If --help is present print help and exit.
-	if *help {
-		fmt.Println("Usage: dup [flags] dir ...")
-		pflag.PrintDefaults()
-		os.Exit(0)
+		dirs = []string{"-"}
 	}
 
-	// Ensure arguments are valid.
-	dirs := pflag.Args()
 	if len(dirs) == 0 {
 		fmt.Println("At least one directory is required.")
 		os.Exit(1)
@@ -229,44 +144,86 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Get list of files, generate pairs and find duplicates.
-	var files [][]string
-	for _, dir := range dirs {
-		files = append(files, listDir(dir, *recursive))
+	// Get list of files from every directory (or stdin, for "-"), remembering which one each file came from.
+	var files []string
+	dirOf := make(map[string]int)
+	seen := make(map[inodeKey]bool)
+	for i, dir := range dirs {
+		var dirFiles []string
+		if dir == "-" {
+			dirFiles = filterFiles(readPaths(os.Stdin, *null), *followHardlinks, seen, filters)
+		} else {
+			dirFiles = listDir(dir, *recursive, *followHardlinks, seen, filters)
+		}
+
+		for _, file := range dirFiles {
+			files = append(files, file)
+			dirOf[file] = i
+		}
+	}
+
+	// Find duplicate groups, restricting to cross-directory groups if requested.
+	dups := findDups(files, *jobs)
+	if *cross {
+		dups = filterCross(dups, dirOf)
 	}
-	pairs := makePairs(files, *cross)
-	dups := findDups(pairs)
 
-	// If no duplicates are found print so and exit.
+	// If no duplicates are found print so (for the table format) and exit.
 	if len(dups) == 0 {
-		fmt.Println("No duplicate files found.")
+		if *format == "table" {
+			fmt.Println("No duplicate files found.")
+		}
+		os.Exit(0)
+	}
+
+	// Machine-readable formats just print the groups for other tooling to consume, and never prompt or modify files.
+	if *format != "table" {
+		if err := printDups(dups, *format); err != nil {
+			log.Fatal(err)
+		}
 		os.Exit(0)
 	}
 
-	// Otherwise print a table of all duplicate files and their matches.
-	fmt.Printf("Found %v duplicate files.\n", len(dups))
+	// Otherwise print every group of duplicate files, keeping the first file of each group as the original.
+	total := 0
+	for _, group := range dups {
+		total += len(group.Files) - 1
+	}
+	fmt.Printf("Found %v duplicate groups (%v duplicate files).\n\n", len(dups), total)
+	printTable(dups)
+
+	// If no action was given on the command line, ask the user how to resolve the duplicates.
+	chosen := *action
+	if chosen == "" {
+		var c rune
+		fmt.Print("Resolve duplicates? [d]elete, [h]ardlink, [s]ymlink or [N]o action: ")
+		fmt.Scanf("%c", &c)
+
+		switch c {
+		case 'd', 'D':
+			chosen = "delete"
+		case 'h', 'H':
+			chosen = "hardlink"
+		case 's', 'S':
+			chosen = "symlink"
+		default:
+			chosen = "report"
+		}
+	}
 
-	tbl := table.New("File", "Matched to")
-	tbl.WithHeaderFormatter(color.New(color.Italic).Add(color.Underline).SprintfFunc())
-	for _, pair := range dups {
-		tbl.AddRow(pair[0], pair[1])
+	if chosen == "report" {
+		fmt.Println("Not modifying any files.")
+		os.Exit(0)
 	}
-	tbl.Print()
 
-	// Ask whether duplicate files are to be deleted.
-	var delete rune
-	fmt.Print("Delete duplicates? [y/N] ")
-	fmt.Scanf("%c", &delete)
-	if delete == 'y' || delete == 'Y' {
-		fmt.Printf("Deleting %v files...\n", len(dups))
-		for _, pair := range dups {
-			err := os.Remove(pair[0])
-			if err != nil {
+	fmt.Printf("Resolving %v duplicate files (%v)...\n", total, chosen)
+	for _, group := range dups {
+		original := group.Files[0]
+		for _, file := range group.Files[1:] {
+			if err := resolve(file, original, chosen); err != nil {
 				log.Println(err)
 			}
 		}
-		fmt.Println("Done.")
-	} else {
-		fmt.Println("Not deleting.")
 	}
+	fmt.Println("Done.")
 }
@@ -0,0 +1,41 @@
+package main
+
+import "sync"
+
+// parallelHash computes hashFn for every file in files using up to jobs worker goroutines, and returns the results
+// in the same order as files. Work items are dispatched over a channel so workers pick up the next file as soon as
+// they finish the previous one.
+func parallelHash(files []string, jobs int, hashFn func(file string) string) []string {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	hashes := make([]string, len(files))
+
+	type job struct {
+		index int
+		file  string
+	}
+
+	jobsCh := make(chan job)
+
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobsCh {
+				hashes[j.index] = hashFn(j.file)
+			}
+		}()
+	}
+
+	for i, file := range files {
+		jobsCh <- job{i, file}
+	}
+	close(jobsCh)
+
+	wg.Wait()
+
+	return hashes
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParallelHashPreservesOrder(t *testing.T) {
+	files := []string{"a", "b", "c", "d", "e"}
+
+	got := parallelHash(files, 3, func(file string) string { return fmt.Sprintf("hash-%v", file) })
+
+	want := []string{"hash-a", "hash-b", "hash-c", "hash-d", "hash-e"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parallelHash = %v, want %v", got, want)
+	}
+}
+
+func TestParallelHashZeroJobsDefaultsToOne(t *testing.T) {
+	files := []string{"a", "b"}
+
+	got := parallelHash(files, 0, func(file string) string { return file })
+
+	want := []string{"a", "b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parallelHash with jobs=0 = %v, want %v", got, want)
+	}
+}
+
+func TestParallelHashEmpty(t *testing.T) {
+	if got := parallelHash(nil, 4, func(file string) string { return file }); len(got) != 0 {
+		t.Errorf("parallelHash(nil) = %v, want empty", got)
+	}
+}